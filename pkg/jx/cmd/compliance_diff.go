@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/onsi/ginkgo/reporters"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	complianceDiffLong = templates.LongDesc(`
+		Diffs the results of two compliance runs, showing which tests regressed,
+		were fixed, or were added or removed between the old and new run
+	`)
+
+	complianceDiffExample = templates.Examples(`
+		# Diff two compliance result archives retrieved via "jx compliance results --save"
+		jx compliance diff old.tar.gz new.tar.gz
+
+		# Diff two compliance runs saved with "jx compliance results --save", by run ID
+		jx compliance diff 20060102-150405 20060102-160000
+	`)
+)
+
+// complianceClient is the subset of the Sonobuoy client used to parse a results archive,
+// satisfied by the client returned from Factory.CreateComplianceClient
+type complianceClient interface {
+	GetTests(reader io.Reader, plugin string) ([]reporters.JUnitTestCase, error)
+}
+
+// ComplianceDiffOptions options for "compliance diff" command
+type ComplianceDiffOptions struct {
+	CommonOptions
+}
+
+// NewCmdComplianceDiff creates a command object for the "compliance diff" action, which
+// compares the results of two compliance runs
+func NewCmdComplianceDiff(f Factory, out io.Writer, errOut io.Writer) *cobra.Command {
+	options := &ComplianceDiffOptions{
+		CommonOptions: CommonOptions{
+			Factory: f,
+			Out:     out,
+			Err:     errOut,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "diff <old> <new>",
+		Short:   "Diffs the results of two compliance runs, given as archive paths or saved run IDs",
+		Long:    complianceDiffLong,
+		Example: complianceDiffExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			CheckErr(err)
+		},
+	}
+
+	return cmd
+}
+
+// testTransition describes how a single test's status changed between two compliance runs
+type testTransition struct {
+	Name      string
+	OldStatus string
+	NewStatus string
+}
+
+// transitionSeverity ranks transitions from most to least severe, worst regressions first
+var transitionSeverity = map[string]int{
+	"regressed": 0,
+	"failing":   1,
+	"skipped":   2,
+	"changed":   3,
+	"removed":   4,
+	"added":     5,
+	"fixed":     6,
+	"unchanged": 7,
+}
+
+func (t testTransition) kind() string {
+	switch {
+	case t.OldStatus == "":
+		if t.NewStatus == "FAILED" {
+			return "failing"
+		}
+		return "added"
+	case t.NewStatus == "":
+		return "removed"
+	case t.OldStatus == t.NewStatus:
+		return "unchanged"
+	case t.NewStatus == "FAILED":
+		return "regressed"
+	case t.OldStatus == "FAILED" && t.NewStatus == "PASSED":
+		return "fixed"
+	case t.NewStatus == "SKIPPED":
+		return "skipped"
+	default:
+		// any other real status change that isn't a regression, a fix or a newly
+		// skipped test, e.g. SKIPPED -> PASSED, SKIPPED -> UNKNOWN, UNKNOWN -> PASSED
+		// or PASSED -> UNKNOWN
+		return "changed"
+	}
+}
+
+// Run implements the "compliance diff" command
+func (o *ComplianceDiffOptions) Run() error {
+	if len(o.Args) != 2 {
+		return errors.New("usage: jx compliance diff <old> <new>")
+	}
+
+	cc, err := o.Factory.CreateComplianceClient()
+	if err != nil {
+		return errors.Wrap(err, "could not create the compliance client")
+	}
+
+	oldTests, err := loadArchiveTests(cc, o.Args[0])
+	if err != nil {
+		return errors.Wrapf(err, "could not load the compliance results from %s", o.Args[0])
+	}
+	newTests, err := loadArchiveTests(cc, o.Args[1])
+	if err != nil {
+		return errors.Wrapf(err, "could not load the compliance results from %s", o.Args[1])
+	}
+
+	transitions := diffTestResults(oldTests, newTests)
+	sort.Sort(bySeverity(transitions))
+	o.printTransitions(transitions)
+	return nil
+}
+
+// resolveComplianceArchivePath resolves a "jx compliance diff" argument to the path of a
+// Sonobuoy results tarball on disk. A run ID previously saved via "jx compliance results
+// --save" takes priority; if it doesn't resolve to a saved run, the argument is treated as
+// a literal path to an archive.
+func resolveComplianceArchivePath(arg string) string {
+	dir, err := complianceRunDir(arg)
+	if err != nil {
+		return arg
+	}
+	candidate := filepath.Join(dir, complianceRunArchiveName)
+	if _, err := os.Stat(candidate); err != nil {
+		return arg
+	}
+	return candidate
+}
+
+// loadArchiveTests loads the flattened test cases from a Sonobuoy compliance results
+// archive, given either a saved run ID or a literal path on disk, as produced by
+// "jx compliance results --save"
+func loadArchiveTests(cc complianceClient, pathOrRunID string) ([]reporters.JUnitTestCase, error) {
+	f, err := os.Open(resolveComplianceArchivePath(pathOrRunID))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the compliance results archive")
+	}
+	defer f.Close()
+
+	resultsReader, errch := untarResults(context.Background(), f, defaultCompliancePlugin)
+	if err := <-errch; err != nil {
+		return nil, errors.Wrap(err, "could not extract the compliance results from archive")
+	}
+
+	gzr, err := gzip.NewReader(resultsReader)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create a gzip reader for the compliance results archive")
+	}
+
+	archive, err := ioutil.ReadAll(gzr)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the compliance results archive")
+	}
+
+	testResults, err := cc.GetTests(bytes.NewReader(archive), "all")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get the results of the compliance tests from the archive")
+	}
+	return testResults, nil
+}
+
+// diffTestResults compares the test cases from two compliance runs and returns every
+// test whose status changed, was added or was removed, in no particular order
+func diffTestResults(oldTests, newTests []reporters.JUnitTestCase) []testTransition {
+	oldStatus := map[string]string{}
+	for _, tc := range oldTests {
+		oldStatus[tc.Name] = status(tc)
+	}
+	newStatus := map[string]string{}
+	for _, tc := range newTests {
+		newStatus[tc.Name] = status(tc)
+	}
+
+	transitions := make([]testTransition, 0)
+	for name, old := range oldStatus {
+		transitions = append(transitions, testTransition{Name: name, OldStatus: old, NewStatus: newStatus[name]})
+	}
+	for name, newS := range newStatus {
+		if _, ok := oldStatus[name]; !ok {
+			transitions = append(transitions, testTransition{Name: name, OldStatus: "", NewStatus: newS})
+		}
+	}
+
+	return filterTransitions(transitions)
+}
+
+// filterTransitions drops transitions whose status did not actually change
+func filterTransitions(transitions []testTransition) []testTransition {
+	out := make([]testTransition, 0)
+	for _, t := range transitions {
+		if t.kind() != "unchanged" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// bySeverity sorts transitions from the most to the least severe regression
+type bySeverity []testTransition
+
+func (s bySeverity) Len() int { return len(s) }
+func (s bySeverity) Less(i, j int) bool {
+	return transitionSeverity[s[i].kind()] < transitionSeverity[s[j].kind()]
+}
+func (s bySeverity) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+func (o *ComplianceDiffOptions) printTransitions(transitions []testTransition) {
+	table := o.CreateTable()
+	table.SetColumnAlign(1, util.ALIGN_LEFT)
+	table.SetColumnAlign(2, util.ALIGN_LEFT)
+	table.SetColumnAlign(3, util.ALIGN_LEFT)
+	table.AddRow("CHANGE", "TEST", "OLD -> NEW")
+	for _, t := range transitions {
+		old := t.OldStatus
+		if old == "" {
+			old = "-"
+		}
+		newS := t.NewStatus
+		if newS == "" {
+			newS = "-"
+		}
+		table.AddRow(t.kind(), t.Name, old+" -> "+newS)
+	}
+	table.Render()
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import "testing"
+
+func TestTestTransitionKind(t *testing.T) {
+	tests := []struct {
+		name      string
+		oldStatus string
+		newStatus string
+		want      string
+	}{
+		{"added passing", "", "PASSED", "added"},
+		{"added failing", "", "FAILED", "failing"},
+		{"removed", "PASSED", "", "removed"},
+		{"unchanged passed", "PASSED", "PASSED", "unchanged"},
+		{"unchanged failed", "FAILED", "FAILED", "unchanged"},
+		{"regressed", "PASSED", "FAILED", "regressed"},
+		{"fixed", "FAILED", "PASSED", "fixed"},
+		{"newly skipped", "PASSED", "SKIPPED", "skipped"},
+		{"skipped to passed", "SKIPPED", "PASSED", "changed"},
+		{"skipped to unknown", "SKIPPED", "UNKNOWN", "changed"},
+		{"unknown to passed", "UNKNOWN", "PASSED", "changed"},
+		{"passed to unknown", "PASSED", "UNKNOWN", "changed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := testTransition{Name: "some test", OldStatus: tt.oldStatus, NewStatus: tt.newStatus}
+			if got := tr.kind(); got != tt.want {
+				t.Errorf("kind() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterTransitionsDropsUnchangedOnly(t *testing.T) {
+	transitions := []testTransition{
+		{Name: "a", OldStatus: "PASSED", NewStatus: "PASSED"},
+		{Name: "b", OldStatus: "SKIPPED", NewStatus: "PASSED"},
+		{Name: "c", OldStatus: "PASSED", NewStatus: "FAILED"},
+	}
+
+	out := filterTransitions(transitions)
+	if len(out) != 2 {
+		t.Fatalf("expected 2 transitions to survive filtering, got %d: %+v", len(out), out)
+	}
+	for _, tr := range out {
+		if tr.Name == "a" {
+			t.Errorf("unchanged transition %q should have been dropped", tr.Name)
+		}
+	}
+}
@@ -2,11 +2,20 @@ package cmd
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
+	"context"
+	"encoding/json"
+	"encoding/xml"
 	"io"
+	"io/ioutil"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/heptio/sonobuoy/pkg/client"
 	"github.com/heptio/sonobuoy/pkg/client/results"
@@ -14,10 +23,12 @@ import (
 	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
 	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/mattn/go-isatty"
 	"github.com/onsi/ginkgo/reporters"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"golang.org/x/sync/errgroup"
+	pb "gopkg.in/cheggaaa/pb.v1"
 )
 
 var (
@@ -28,12 +39,41 @@ var (
 	complianceResultsExample = templates.Examples(`
 		# Show the compliance results
 		jx compliance results
+
+		# Save the compliance results as JUnit XML
+		jx compliance results --output=junit --output-file=results.xml
+
+		# Fail the build if any non-skipped test failed
+		jx compliance results --fail-on-failure
 	`)
 )
 
+// compliance output formats supported by "compliance results"
+const (
+	complianceOutputTable = "table"
+	complianceOutputJSON  = "json"
+	complianceOutputJUnit = "junit"
+	complianceOutputXML   = "xml"
+)
+
 // ComplianceResultsOptions options for "compliance results" command
 type ComplianceResultsOptions struct {
 	CommonOptions
+
+	Output     string
+	OutputFile string
+
+	Focus         string
+	Skip          string
+	Status        string
+	FailOnFailure bool
+
+	Save  bool
+	RunID string
+
+	NoProgress bool
+
+	Plugin string
 }
 
 // NewCmdComplianceResults creates a command object for the "compliance results" action, which
@@ -60,16 +100,56 @@ func NewCmdComplianceResults(f Factory, out io.Writer, errOut io.Writer) *cobra.
 		},
 	}
 
+	cmd.Flags().StringVarP(&options.Output, "output", "o", complianceOutputTable, "The output format of the results: table, json, junit or xml")
+	cmd.Flags().StringVar(&options.OutputFile, "output-file", "", "The file to write the results to instead of stdout (ignored for the table output)")
+	cmd.Flags().StringVar(&options.Focus, "focus", "", "Only include tests whose name matches this regular expression")
+	cmd.Flags().StringVar(&options.Skip, "skip", "", "Exclude tests whose name matches this regular expression")
+	cmd.Flags().StringVar(&options.Status, "status", "", "Only include tests with one of these comma separated statuses: FAILED, PASSED, SKIPPED")
+	cmd.Flags().BoolVar(&options.FailOnFailure, "fail-on-failure", false, "Exit with a non-zero status if any non-skipped test failed")
+	cmd.Flags().BoolVar(&options.Save, "save", false, "Save the retrieved compliance results under ~/.jx/compliance so they can be listed with \"jx compliance runs\" and diffed later")
+	cmd.Flags().StringVar(&options.RunID, "run", "", "Re-render a compliance run previously stored with --save, identified by the ID shown by \"jx compliance runs\", instead of querying the cluster")
+	cmd.Flags().BoolVar(&options.NoProgress, "no-progress", false, "Disable the progress bar shown on stderr while the compliance results archive is downloaded")
+	cmd.Flags().StringVar(&options.Plugin, "plugin", defaultCompliancePlugin, "The Sonobuoy plugin whose results to show, e.g. e2e, systemd-logs")
+
 	return cmd
 }
 
 // Run implements the "compliance results" command
 func (o *ComplianceResultsOptions) Run() error {
+	switch o.Output {
+	case complianceOutputTable, complianceOutputJSON, complianceOutputJUnit, complianceOutputXML:
+	default:
+		return errors.Errorf("invalid --output value %q, must be one of: table, json, junit, xml", o.Output)
+	}
+
+	var focusRe, skipRe *regexp.Regexp
+	var err error
+	if o.Focus != "" {
+		focusRe, err = regexp.Compile(o.Focus)
+		if err != nil {
+			return errors.Wrap(err, "invalid --focus regex")
+		}
+	}
+	if o.Skip != "" {
+		skipRe, err = regexp.Compile(o.Skip)
+		if err != nil {
+			return errors.Wrap(err, "invalid --skip regex")
+		}
+	}
+	statusFilter, err := parseStatusFilter(o.Status)
+	if err != nil {
+		return err
+	}
+
 	cc, err := o.Factory.CreateComplianceClient()
 	if err != nil {
 		return errors.Wrap(err, "could not create the compliance client")
 	}
 
+	if o.RunID != "" {
+		return o.runFromSaved(cc, focusRe, skipRe, statusFilter)
+	}
+
 	status, err := cc.GetStatus(complianceNamespace)
 	if err != nil {
 		return errors.Wrap(err, "failed to retrieve the compliance status")
@@ -84,30 +164,84 @@ func (o *ComplianceResultsOptions) Run() error {
 		Namespace: complianceNamespace,
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	defer signal.Stop(sigCh)
+	go func() {
+		select {
+		case <-sigCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
 	reader, errch := cc.RetrieveResults(cfg)
-	eg := &errgroup.Group{}
+	var src io.Reader = reader
+
+	var runID string
+	var runDir string
+	if o.Save {
+		runID = time.Now().UTC().Format("20060102-150405")
+		runDir, err = complianceRunDir(runID)
+		if err != nil {
+			return errors.Wrap(err, "could not determine the compliance runs directory")
+		}
+		if err := os.MkdirAll(runDir, 0755); err != nil {
+			return errors.Wrap(err, "could not create the compliance runs directory")
+		}
+		archiveFile, err := os.Create(filepath.Join(runDir, complianceRunArchiveName))
+		if err != nil {
+			return errors.Wrap(err, "could not create the compliance run archive file")
+		}
+		defer archiveFile.Close()
+		src = io.TeeReader(src, archiveFile)
+	}
+
+	if !o.NoProgress && isatty.IsTerminal(os.Stdout.Fd()) {
+		bar := pb.New64(0)
+		bar.SetUnits(pb.U_BYTES)
+		bar.Output = o.Err
+		bar.Start()
+		defer bar.Finish()
+		src = bar.NewProxyReader(src)
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
 	eg.Go(func() error { return <-errch })
 	eg.Go(func() error {
-		resultsReader, errch := untarResults(reader)
+		resultsReader, errch := untarResults(ctx, ctxReader{ctx: ctx, r: src}, o.Plugin)
+		if err := <-errch; err != nil {
+			return errors.Wrap(err, "could not extract the compliance results from archive")
+		}
+
 		gzr, err := gzip.NewReader(resultsReader)
 		if err != nil {
 			return errors.Wrap(err, "could not create a gzip reader for compliance results ")
 		}
 
-		testResults, err := cc.GetTests(gzr, "all")
+		archive, err := ioutil.ReadAll(gzr)
 		if err != nil {
-			return errors.Wrap(err, "could not get the results of the compliance tests from the archive")
+			return errors.Wrap(err, "could not read the compliance results archive")
 		}
-		testResults = filterTests(
-			func(tc reporters.JUnitTestCase) bool {
-				return !results.Skipped(tc)
-			}, testResults)
-		sort.Sort(StatusSortedTestCases(testResults))
-		o.printResults(testResults)
 
-		err = <-errch
+		failed, summary, err := o.renderArchive(cc, archive, focusRe, skipRe, statusFilter)
 		if err != nil {
-			return errors.Wrap(err, "could not extract the compliance results from archive")
+			return err
+		}
+
+		if o.Save {
+			summary.ID = runID
+			summary.Timestamp = time.Now().UTC().Format(time.RFC3339)
+			summary.K8sVersion = o.complianceK8sVersion()
+			if err := saveComplianceRunSummary(summary); err != nil {
+				return errors.Wrap(err, "could not save the compliance run summary")
+			}
+		}
+
+		if o.FailOnFailure && failed {
+			o.Exit(1)
 		}
 		return nil
 	})
@@ -119,6 +253,172 @@ func (o *ComplianceResultsOptions) Run() error {
 	return nil
 }
 
+// runFromSaved re-renders a compliance run previously stored with --save, without
+// contacting the cluster
+func (o *ComplianceResultsOptions) runFromSaved(cc complianceClient, focusRe, skipRe *regexp.Regexp, statusFilter map[string]bool) error {
+	dir, err := complianceRunDir(o.RunID)
+	if err != nil {
+		return errors.Wrap(err, "could not determine the compliance runs directory")
+	}
+
+	f, err := os.Open(filepath.Join(dir, complianceRunArchiveName))
+	if err != nil {
+		return errors.Wrapf(err, "could not find a saved compliance run with ID %q", o.RunID)
+	}
+	defer f.Close()
+
+	resultsReader, errch := untarResults(context.Background(), f, o.Plugin)
+	if err := <-errch; err != nil {
+		return errors.Wrap(err, "could not extract the saved compliance results archive")
+	}
+
+	gzr, err := gzip.NewReader(resultsReader)
+	if err != nil {
+		return errors.Wrap(err, "could not create a gzip reader for the saved compliance results")
+	}
+
+	archive, err := ioutil.ReadAll(gzr)
+	if err != nil {
+		return errors.Wrap(err, "could not read the saved compliance results archive")
+	}
+
+	failed, _, err := o.renderArchive(cc, archive, focusRe, skipRe, statusFilter)
+	if err != nil {
+		return err
+	}
+
+	if o.FailOnFailure && failed {
+		o.Exit(1)
+	}
+	return nil
+}
+
+// renderArchive prints or writes the test results held in a decompressed compliance
+// results archive, applying the focus/skip/status filters, and returns whether any test
+// failed along with a summary of the pass/fail/skip counts
+func (o *ComplianceResultsOptions) renderArchive(cc complianceClient, archive []byte, focusRe, skipRe *regexp.Regexp, statusFilter map[string]bool) (bool, complianceRunSummary, error) {
+	// with no explicit --status filter, skipped tests are excluded by default in every
+	// output format, matching the behavior of the original table-only renderer
+	matches := func(tc reporters.JUnitTestCase) bool {
+		if statusFilter == nil && results.Skipped(tc) {
+			return false
+		}
+		return matchesTestCase(tc, focusRe, skipRe, statusFilter)
+	}
+
+	if o.Output == complianceOutputTable {
+		testResults, err := cc.GetTests(bytes.NewReader(archive), "all")
+		if err != nil {
+			return false, complianceRunSummary{}, errors.Wrap(err, "could not get the results of the compliance tests from the archive")
+		}
+		testResults = filterTests(matches, testResults)
+		sort.Sort(StatusSortedTestCases(testResults))
+		o.printResults(testResults)
+
+		passed, failedCount, skipped := countStatuses(testResults)
+		return failedCount > 0, complianceRunSummary{Passed: passed, Failed: failedCount, Skipped: skipped}, nil
+	}
+
+	suite, err := loadJUnitSuite(bytes.NewReader(archive))
+	if err != nil {
+		return false, complianceRunSummary{}, errors.Wrap(err, "could not get the results of the compliance tests from the archive")
+	}
+	suite.TestCases = filterTests(matches, suite.TestCases)
+	suite.Tests = len(suite.TestCases)
+	suite.Failures = len(filterTests(func(tc reporters.JUnitTestCase) bool { return results.Failed(tc) }, suite.TestCases))
+	if err := o.writeSuite(suite); err != nil {
+		return false, complianceRunSummary{}, errors.Wrap(err, "could not write the compliance results")
+	}
+
+	passed, failedCount, skipped := countStatuses(suite.TestCases)
+	return suite.Failures > 0, complianceRunSummary{Passed: passed, Failed: failedCount, Skipped: skipped}, nil
+}
+
+// complianceK8sVersion returns the version of the cluster the compliance run targeted, or
+// an empty string if it could not be determined
+func (o *ComplianceResultsOptions) complianceK8sVersion() string {
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return ""
+	}
+	version, err := kubeClient.Discovery().ServerVersion()
+	if err != nil {
+		return ""
+	}
+	return version.GitVersion
+}
+
+// countStatuses counts how many of the given test cases passed, failed or were skipped
+func countStatuses(testCases []reporters.JUnitTestCase) (passed, failed, skipped int) {
+	for _, tc := range testCases {
+		switch status(tc) {
+		case "PASSED":
+			passed++
+		case "FAILED":
+			failed++
+		case "SKIPPED":
+			skipped++
+		}
+	}
+	return
+}
+
+// writeSuite renders the full JUnit test suite as JSON, JUnit or raw XML, either to
+// o.OutputFile or to o.Out when no file was given
+func (o *ComplianceResultsOptions) writeSuite(suite *reporters.JUnitTestSuite) error {
+	var data []byte
+	var err error
+
+	switch o.Output {
+	case complianceOutputJSON:
+		data, err = json.MarshalIndent(suite, "", "  ")
+	case complianceOutputJUnit, complianceOutputXML:
+		data, err = xml.MarshalIndent(suite, "", "  ")
+		if err == nil {
+			data = append([]byte(xml.Header), data...)
+		}
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not marshal the compliance results")
+	}
+
+	if o.OutputFile != "" {
+		return ioutil.WriteFile(o.OutputFile, data, 0644)
+	}
+	_, err = o.Out.Write(append(data, '\n'))
+	return err
+}
+
+// loadJUnitSuite reconstructs the full reporters.JUnitTestSuite (suite name, timing,
+// failure messages) from the JUnit XML file embedded in a compliance results archive,
+// rather than the flattened []JUnitTestCase returned by client.GetTests
+func loadJUnitSuite(archive io.Reader) (*reporters.JUnitTestSuite, error) {
+	tr := tar.NewReader(archive)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, errors.New("no junit results file found in the compliance results archive")
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the compliance results archive")
+		}
+		if header.Typeflag != tar.TypeReg || !strings.HasSuffix(header.Name, "junit.xml") {
+			continue
+		}
+
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the junit results file")
+		}
+
+		suite := &reporters.JUnitTestSuite{}
+		if err := xml.Unmarshal(data, suite); err != nil {
+			return nil, errors.Wrap(err, "failed to parse the junit results file")
+		}
+		return suite, nil
+	}
+}
+
 // Exit the main goroutine with status
 func (o *ComplianceResultsOptions) Exit(status int) {
 	os.Exit(status)
@@ -165,34 +465,156 @@ func status(junitResult reporters.JUnitTestCase) string {
 	}
 }
 
-func untarResults(src io.Reader) (io.Reader, <-chan error) {
+// defaultCompliancePlugin is the Sonobuoy plugin whose results "compliance results" renders
+// when --plugin is not given
+const defaultCompliancePlugin = "e2e"
+
+// ctxReader wraps a reader so that a Read blocked on a stalled compliance results download
+// returns as soon as ctx is cancelled, instead of only being interruptible between tar
+// entries. The underlying Read may still be running when this returns; that goroutine is
+// abandoned and exits whenever the stalled Read eventually completes or errors.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c ctxReader) Read(p []byte) (int, error) {
+	type result struct {
+		n   int
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		n, err := c.r.Read(p)
+		ch <- result{n, err}
+	}()
+
+	select {
+	case <-c.ctx.Done():
+		return 0, c.ctx.Err()
+	case res := <-ch:
+		return res.n, res.err
+	}
+}
+
+// untarResults walks a Sonobuoy compliance results archive and returns the raw (still
+// gzip compressed) results blob for the requested plugin
+func untarResults(ctx context.Context, src io.Reader, plugin string) (io.Reader, <-chan error) {
 	ec := make(chan error, 1)
+
+	blobs, err := walkResultsArchive(ctx, src)
+	if err != nil {
+		ec <- err
+		close(ec)
+		return nil, ec
+	}
+
+	blob, ok := blobs[plugin]
+	if !ok {
+		ec <- errors.Errorf("no results found for plugin %q in the compliance results archive (available: %s)", plugin, strings.Join(pluginNames(blobs), ", "))
+		close(ec)
+		return nil, ec
+	}
+
+	close(ec)
+	return bytes.NewReader(blob), ec
+}
+
+// walkResultsArchive iterates every entry of a Sonobuoy compliance results archive and
+// returns the raw results blob of every plugin it finds, keyed by plugin name, so plugins
+// other than e2e (systemd-logs, custom plugins, ...) can be surfaced too
+func walkResultsArchive(ctx context.Context, src io.Reader) (map[string][]byte, error) {
+	blobs := map[string][]byte{}
 	tarReader := tar.NewReader(src)
 	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
 		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
-			if err != io.EOF {
-				ec <- err
-				return nil, ec
-			} else {
-				ec <- errors.New("no compliance results archive found")
-				return nil, ec
-			}
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg || !strings.HasSuffix(header.Name, ".tar.gz") {
+			continue
+		}
+
+		name := filepath.Clean(header.Name)
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			continue
+		}
+
+		data, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			return nil, err
 		}
-		if strings.HasSuffix(header.Name, ".tar.gz") {
-			reader, writer := io.Pipe()
-			go func(writer *io.PipeWriter, ec chan error) {
-				defer writer.Close()
-				defer close(ec)
-				_, err := io.Copy(writer, tarReader)
-				if err != nil {
-					ec <- err
-				}
-			}(writer, ec)
-			return reader, nil
+		blobs[pluginNameFromPath(name)] = data
+	}
+
+	if len(blobs) == 0 {
+		return nil, errors.New("no compliance results archive found")
+	}
+	return blobs, nil
+}
+
+// pluginNameFromPath derives the Sonobuoy plugin name a results blob belongs to from its
+// path within the archive, e.g. "plugins/e2e/results/e2e.tar.gz" -> "e2e"
+func pluginNameFromPath(path string) string {
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	if len(parts) > 2 && parts[0] == "plugins" {
+		return parts[1]
+	}
+	dir := filepath.Dir(path)
+	if dir != "." && dir != string(filepath.Separator) {
+		return filepath.Base(dir)
+	}
+	return strings.TrimSuffix(filepath.Base(path), ".tar.gz")
+}
+
+// pluginNames returns the sorted plugin names held in a results blob map, for error messages
+func pluginNames(blobs map[string][]byte) []string {
+	names := make([]string, 0, len(blobs))
+	for name := range blobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseStatusFilter turns a comma separated --status value into a lookup set of the
+// statuses to keep, or nil if no filter was given
+func parseStatusFilter(value string) (map[string]bool, error) {
+	if value == "" {
+		return nil, nil
+	}
+	filter := map[string]bool{}
+	for _, s := range strings.Split(value, ",") {
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if _, ok := statuses[s]; !ok {
+			return nil, errors.Errorf("invalid --status value %q, must be one of: FAILED, PASSED, SKIPPED", s)
 		}
+		filter[s] = true
+	}
+	return filter, nil
+}
+
+// matchesTestCase reports whether a test case passes the --focus, --skip and --status filters
+func matchesTestCase(tc reporters.JUnitTestCase, focus, skip *regexp.Regexp, statusFilter map[string]bool) bool {
+	if focus != nil && !focus.MatchString(tc.Name) {
+		return false
+	}
+	if skip != nil && skip.MatchString(tc.Name) {
+		return false
+	}
+	if statusFilter != nil && !statusFilter[status(tc)] {
+		return false
 	}
-	return nil, ec
+	return true
 }
 
 func filterTests(predicate func(testCase reporters.JUnitTestCase) bool, testCases []reporters.JUnitTestCase) []reporters.JUnitTestCase {
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/onsi/ginkgo/reporters"
+)
+
+func TestParseStatusFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]bool
+		wantErr bool
+	}{
+		{"empty means no filter", "", nil, false},
+		{"single status", "failed", map[string]bool{"FAILED": true}, false},
+		{"mixed case with spaces", " Passed , skipped ", map[string]bool{"PASSED": true, "SKIPPED": true}, false},
+		{"invalid status", "bogus", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStatusFilter(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error for %q, got none", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseStatusFilter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+			for k := range tt.want {
+				if !got[k] {
+					t.Errorf("parseStatusFilter(%q) missing status %q", tt.value, k)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchesTestCase(t *testing.T) {
+	passed := reporters.JUnitTestCase{Name: "should create a pod"}
+	failed := reporters.JUnitTestCase{Name: "should delete a pod", FailureMessage: &reporters.JUnitFailureMessage{Message: "boom"}}
+
+	if !matchesTestCase(passed, nil, nil, nil) {
+		t.Error("expected a test case to match with no filters")
+	}
+	if !matchesTestCase(passed, regexp.MustCompile("create"), nil, nil) {
+		t.Error("expected --focus to match on a substring of the test name")
+	}
+	if matchesTestCase(passed, regexp.MustCompile("delete"), nil, nil) {
+		t.Error("expected --focus to exclude a non-matching test name")
+	}
+	if matchesTestCase(passed, nil, regexp.MustCompile("create"), nil) {
+		t.Error("expected --skip to exclude a matching test name")
+	}
+	if !matchesTestCase(failed, nil, nil, map[string]bool{"FAILED": true}) {
+		t.Error("expected --status=FAILED to match a failed test")
+	}
+	if matchesTestCase(passed, nil, nil, map[string]bool{"FAILED": true}) {
+		t.Error("expected --status=FAILED to exclude a passed test")
+	}
+}
+
+func TestPluginNameFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"plugins/e2e/results/e2e.tar.gz", "e2e"},
+		{"plugins/systemd-logs/results/systemd-logs.tar.gz", "systemd-logs"},
+		{"e2e.tar.gz", "e2e"},
+	}
+	for _, tt := range tests {
+		if got := pluginNameFromPath(tt.path); got != tt.want {
+			t.Errorf("pluginNameFromPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// tarArchive builds an in-memory tar archive from the given entries for use in
+// walkResultsArchive tests
+func tarArchive(t *testing.T, entries map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, body := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Size: int64(len(body))}); err != nil {
+			t.Fatalf("failed to write tar header for %q: %v", name, err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatalf("failed to write tar body for %q: %v", name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestWalkResultsArchive(t *testing.T) {
+	archive := tarArchive(t, map[string]string{
+		"plugins/e2e/results/e2e.tar.gz":                   "e2e-blob",
+		"plugins/systemd-logs/results/systemd-logs.tar.gz": "systemd-logs-blob",
+		"plugins/e2e/results/junit.xml":                    "<testsuite></testsuite>",
+	})
+
+	blobs, err := walkResultsArchive(context.Background(), bytes.NewReader(archive))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(blobs["e2e"]) != "e2e-blob" {
+		t.Errorf("blobs[e2e] = %q, want %q", blobs["e2e"], "e2e-blob")
+	}
+	if string(blobs["systemd-logs"]) != "systemd-logs-blob" {
+		t.Errorf("blobs[systemd-logs] = %q, want %q", blobs["systemd-logs"], "systemd-logs-blob")
+	}
+	if len(blobs) != 2 {
+		t.Errorf("expected only the two .tar.gz entries to be surfaced, got %d: %v", len(blobs), blobs)
+	}
+}
+
+func TestWalkResultsArchiveRejectsPathTraversal(t *testing.T) {
+	archive := tarArchive(t, map[string]string{
+		"../../etc/passwd.tar.gz": "malicious",
+	})
+
+	_, err := walkResultsArchive(context.Background(), bytes.NewReader(archive))
+	if err == nil {
+		t.Fatal("expected an error, got none: a path-traversing entry must not be surfaced as a result")
+	}
+}
+
+func TestWalkResultsArchiveNoResults(t *testing.T) {
+	archive := tarArchive(t, map[string]string{
+		"plugins/e2e/results/junit.xml": "<testsuite></testsuite>",
+	})
+
+	_, err := walkResultsArchive(context.Background(), bytes.NewReader(archive))
+	if err == nil {
+		t.Fatal("expected an error when the archive has no .tar.gz results entries")
+	}
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// complianceRunArchiveName is the name the raw Sonobuoy results tarball is saved under
+	complianceRunArchiveName = "results.tar.gz"
+
+	// complianceRunSummaryName is the name the parsed run summary is saved under
+	complianceRunSummaryName = "summary.json"
+)
+
+var (
+	complianceRunsLong = templates.LongDesc(`
+		Lists the compliance runs saved via "jx compliance results --save"
+	`)
+
+	complianceRunsExample = templates.Examples(`
+		# List the saved compliance runs
+		jx compliance runs
+	`)
+)
+
+// complianceRunSummary is the parsed summary of a saved compliance run
+type complianceRunSummary struct {
+	ID         string `json:"id"`
+	Timestamp  string `json:"timestamp"`
+	K8sVersion string `json:"k8sVersion,omitempty"`
+	Passed     int    `json:"passed"`
+	Failed     int    `json:"failed"`
+	Skipped    int    `json:"skipped"`
+}
+
+// ComplianceRunsOptions options for "compliance runs" command
+type ComplianceRunsOptions struct {
+	CommonOptions
+}
+
+// NewCmdComplianceRuns creates a command object for the "compliance runs" action, which
+// lists the compliance runs saved under ~/.jx/compliance
+func NewCmdComplianceRuns(f Factory, out io.Writer, errOut io.Writer) *cobra.Command {
+	options := &ComplianceRunsOptions{
+		CommonOptions: CommonOptions{
+			Factory: f,
+			Out:     out,
+			Err:     errOut,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "runs",
+		Short:   "Lists the saved compliance runs",
+		Long:    complianceRunsLong,
+		Example: complianceRunsExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			CheckErr(err)
+		},
+	}
+
+	return cmd
+}
+
+// Run implements the "compliance runs" command
+func (o *ComplianceRunsOptions) Run() error {
+	summaries, err := listComplianceRuns()
+	if err != nil {
+		return errors.Wrap(err, "could not list the saved compliance runs")
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Timestamp > summaries[j].Timestamp })
+
+	table := o.CreateTable()
+	table.SetColumnAlign(1, util.ALIGN_LEFT)
+	table.AddRow("ID", "TIMESTAMP", "K8S VERSION", "PASSED", "FAILED", "SKIPPED")
+	for _, s := range summaries {
+		table.AddRow(s.ID, s.Timestamp, s.K8sVersion, strconv.Itoa(s.Passed), strconv.Itoa(s.Failed), strconv.Itoa(s.Skipped))
+	}
+	table.Render()
+	return nil
+}
+
+// complianceRunsBaseDir returns the directory that saved compliance runs are stored under,
+// creating it if it does not already exist
+func complianceRunsBaseDir() (string, error) {
+	dir, err := util.ConfigDir()
+	if err != nil {
+		return "", errors.Wrap(err, "could not determine the jx config directory")
+	}
+	base := filepath.Join(dir, "compliance")
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return "", errors.Wrap(err, "could not create the compliance runs directory")
+	}
+	return base, nil
+}
+
+// complianceRunDir returns the directory a single compliance run with the given ID is
+// stored under
+func complianceRunDir(id string) (string, error) {
+	base, err := complianceRunsBaseDir()
+	if err != nil {
+		return "", err
+	}
+
+	name := filepath.Clean(id)
+	if id == "" || filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") || strings.ContainsRune(name, filepath.Separator) {
+		return "", errors.Errorf("invalid compliance run ID %q", id)
+	}
+	return filepath.Join(base, name), nil
+}
+
+// listComplianceRuns loads the summaries of every saved compliance run
+func listComplianceRuns() ([]complianceRunSummary, error) {
+	base, err := complianceRunsBaseDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := ioutil.ReadDir(base)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []complianceRunSummary{}, nil
+		}
+		return nil, errors.Wrap(err, "could not read the compliance runs directory")
+	}
+
+	summaries := make([]complianceRunSummary, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		summary, err := loadComplianceRunSummary(entry.Name())
+		if err != nil {
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// loadComplianceRunSummary loads the saved summary for a single compliance run
+func loadComplianceRunSummary(id string) (complianceRunSummary, error) {
+	dir, err := complianceRunDir(id)
+	if err != nil {
+		return complianceRunSummary{}, err
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, complianceRunSummaryName))
+	if err != nil {
+		return complianceRunSummary{}, errors.Wrap(err, "could not read the compliance run summary")
+	}
+
+	summary := complianceRunSummary{}
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return complianceRunSummary{}, errors.Wrap(err, "could not parse the compliance run summary")
+	}
+	return summary, nil
+}
+
+// saveComplianceRunSummary writes the summary of a saved compliance run to disk
+func saveComplianceRunSummary(summary complianceRunSummary) error {
+	dir, err := complianceRunDir(summary.ID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "could not marshal the compliance run summary")
+	}
+	return ioutil.WriteFile(filepath.Join(dir, complianceRunSummaryName), data, 0644)
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+)
+
+// withTempHome points $HOME at a temporary directory for the duration of the test, so
+// util.ConfigDir() (and therefore complianceRunsBaseDir) resolves under a throwaway
+// directory instead of the real user's ~/.jx
+func withTempHome(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	old, hadOld := os.LookupEnv("HOME")
+	if err := os.Setenv("HOME", home); err != nil {
+		t.Fatalf("failed to set HOME: %v", err)
+	}
+	t.Cleanup(func() {
+		if hadOld {
+			os.Setenv("HOME", old)
+		} else {
+			os.Unsetenv("HOME")
+		}
+	})
+}
+
+func TestSaveAndListComplianceRunSummary(t *testing.T) {
+	withTempHome(t)
+
+	summary := complianceRunSummary{
+		ID:         "20060102-150405",
+		Timestamp:  "2006-01-02T15:04:05Z",
+		K8sVersion: "v1.18.0",
+		Passed:     10,
+		Failed:     1,
+		Skipped:    2,
+	}
+
+	dir, err := complianceRunDir(summary.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("failed to create the run directory: %v", err)
+	}
+
+	if err := saveComplianceRunSummary(summary); err != nil {
+		t.Fatalf("saveComplianceRunSummary failed: %v", err)
+	}
+
+	loaded, err := loadComplianceRunSummary(summary.ID)
+	if err != nil {
+		t.Fatalf("loadComplianceRunSummary failed: %v", err)
+	}
+	if loaded != summary {
+		t.Fatalf("loadComplianceRunSummary() = %+v, want %+v", loaded, summary)
+	}
+
+	summaries, err := listComplianceRuns()
+	if err != nil {
+		t.Fatalf("listComplianceRuns failed: %v", err)
+	}
+	if len(summaries) != 1 || summaries[0] != summary {
+		t.Fatalf("listComplianceRuns() = %+v, want [%+v]", summaries, summary)
+	}
+}
+
+func TestListComplianceRunsEmpty(t *testing.T) {
+	withTempHome(t)
+
+	summaries, err := listComplianceRuns()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(summaries) != 0 {
+		t.Fatalf("expected no saved runs, got %+v", summaries)
+	}
+}
+
+func TestComplianceRunDirRejectsPathTraversal(t *testing.T) {
+	withTempHome(t)
+
+	for _, id := range []string{"../escape", "..", "/etc/passwd", "foo/../../bar", ""} {
+		if _, err := complianceRunDir(id); err == nil {
+			t.Errorf("complianceRunDir(%q) = nil error, want an error rejecting the ID", id)
+		}
+	}
+}